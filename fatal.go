@@ -0,0 +1,176 @@
+/*
+Copyright (c) 2018-2019 Drew DeVault
+Copyright (c) 2021-2022 Robin Jarry
+
+The MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package jlog
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// defaultMaxStackBytes caps how much of a goroutine stack dump is attached
+// to a Fatal/Exit record or a LogBacktraceAt match.
+const defaultMaxStackBytes = 1 << 20 // 1 MiB
+
+var maxStackBytes int32 = defaultMaxStackBytes
+
+// SetMaxStackBytes overrides the default 1MiB cap on stack dumps attached
+// to Fatalf/ExitDepthf and LogBacktraceAt matches.
+func SetMaxStackBytes(n int) {
+	atomic.StoreInt32(&maxStackBytes, int32(n))
+}
+
+func init() {
+	flag.Var(&logBacktraceAt, "log_backtrace_at", "comma-separated list of file:line to emit a stack trace for")
+}
+
+// backtraceAt implements flag.Value for -log_backtrace_at: a set of
+// "file:line" locations that, when a log call originates from them, get a
+// stack trace appended to that single record.
+type backtraceAt struct {
+	mu  sync.Mutex
+	set map[string]bool
+}
+
+var logBacktraceAt backtraceAt
+
+func (b *backtraceAt) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	locations := make([]string, 0, len(b.set))
+	for loc := range b.set {
+		locations = append(locations, loc)
+	}
+	return strings.Join(locations, ",")
+}
+
+func (b *backtraceAt) Set(value string) error {
+	set := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		if entry == "" {
+			continue
+		}
+		set[entry] = true
+	}
+	b.mu.Lock()
+	b.set = set
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *backtraceAt) matches(file string, line int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.set) == 0 {
+		return false
+	}
+	return b.set[file+":"+strconv.Itoa(line)]
+}
+
+// stackTrace dumps goroutine stacks, formatted like runtime/debug.Stack,
+// capped at maxStackBytes. Pass all=true to include every goroutine, as
+// Fatalf does; LogBacktraceAt matches only want the calling goroutine.
+func stackTrace(all bool) []byte {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, all)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	if limit := int(atomic.LoadInt32(&maxStackBytes)); limit > 0 && len(buf) > limit {
+		buf = buf[:limit]
+	}
+	return buf
+}
+
+// Fatalf logs message at FATAL severity, dumps every goroutine's stack,
+// flushes all registered sinks, then calls os.Exit(255) -- glog's Fatal
+// semantics.
+func Fatalf(message string, args ...interface{}) {
+	fatal(3, message, args, 255)
+}
+
+// ExitDepthf logs message at FATAL severity, flushes all registered sinks,
+// then calls os.Exit(1). depth lets a wrapper around ExitDepthf attribute
+// the record to its own caller instead of itself, same as glog's ExitDepth.
+func ExitDepthf(depth int, message string, args ...interface{}) {
+	fatal(3+depth, message, args, 1)
+}
+
+func fatal(calldepth int, message string, args []interface{}, code int) {
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	file, line := callerLocation(calldepth)
+	stack := stackTrace(true)
+
+	fmt.Fprintf(os.Stderr, "%s: %s:%d: %s\n%s", SeverityFatal, file, line, message, stack)
+
+	// Enqueue the FATAL record on logQueue instead of dispatching it
+	// synchronously: emit()'d records queued ahead of this call are only
+	// delivered by the background worker, so dispatching straight to the
+	// sinks here would let the fatal line jump ahead of log lines that were
+	// actually emitted before it. Flush then waits for this record (and
+	// everything queued before it) to drain in order.
+	startWorker()
+	logQueue <- logItem{severity: SeverityFatal, file: file, line: line, msg: message,
+		fields: []Field{{Key: "stacktrace", Value: string(stack)}}}
+	Flush()
+	os.Exit(code)
+}
+
+// InstallSignalFlush arranges for a SIGTERM or SIGINT to flush every
+// registered sink before the process exits, so buffered log lines are not
+// lost on a normal shutdown signal. It is opt-in: jlog never installs this
+// on its own, since a host process almost always has its own SIGTERM/SIGINT
+// handling for graceful shutdown, and Go fans a signal out to every
+// registered channel -- an unconditional handler here would race the
+// host's own shutdown path and could os.Exit out from under it. Callers
+// that want jlog to own process exit on these signals call this explicitly,
+// typically right after InitLog/InitLogWithOptions.
+func InstallSignalFlush() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	go func() {
+		sig := <-sigCh
+		Flush()
+		signal.Stop(sigCh)
+		if sn, ok := sig.(syscall.Signal); ok {
+			os.Exit(128 + int(sn))
+		}
+		os.Exit(1)
+	}()
+}
@@ -0,0 +1,122 @@
+/*
+Copyright (c) 2018-2019 Drew DeVault
+Copyright (c) 2021-2022 Robin Jarry
+
+The MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package jlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// fileSink is the built-in Sink used by InitLog/InitLogWithOptions when no
+// explicit Options.Sinks are given: it reproduces the package's historical
+// stderr+file text format, or one JSON object per line when format is
+// FormatJSON.
+type fileSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format Format
+}
+
+func newFileSink(w io.Writer, format Format) *fileSink {
+	return &fileSink{w: w, format: format}
+}
+
+func (s *fileSink) Emit(severity Severity, file string, line int, msg string, fields []Field) {
+	var line2 string
+	if s.format == FormatJSON {
+		line2 = formatJSON(severity, file, line, msg, fields)
+	} else {
+		line2 = formatText(severity, file, line, msg, fields)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprint(s.w, line2) //nolint:errcheck // we can't do anything with what we log
+}
+
+// formatText mirrors the stdlib log package's "date time file:line: msg"
+// layout (Ldate|Ltime|Lshortfile), since fileSink resolves file/line itself
+// rather than handing writes to a *log.Logger.
+func formatText(severity Severity, file string, line int, msg string, fields []Field) string {
+	var prefix string
+	withLoc := true
+
+	switch severity {
+	case SeverityInfo:
+		prefix, withLoc = "[jasmd] ", false
+	case SeverityWarn:
+		prefix = "WARNING: "
+	case SeverityDebug:
+		prefix = "DEBUG: "
+	case SeverityError:
+		prefix = "ERROR: "
+	case SeverityFatal:
+		prefix = "FATAL: "
+	}
+
+	suffix := fieldsToText(fields)
+	if !withLoc {
+		return prefix + msg + suffix + "\n"
+	}
+	return prefix + time.Now().Format("2006/01/02 15:04:05") + " " +
+		file + ":" + strconv.Itoa(line) + ": " + msg + suffix + "\n"
+}
+
+// formatJSON renders one JSON object per line: {"ts","level","caller","msg",
+// then each field}, mirroring klog's InfoS and the ecosystem's structured
+// logging convention.
+func formatJSON(severity Severity, file string, line int, msg string, fields []Field) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeJSONField(&buf, "ts", time.Now().Format(time.RFC3339Nano), true)
+	writeJSONField(&buf, "level", severity.String(), false)
+	writeJSONField(&buf, "caller", file+":"+strconv.Itoa(line), false)
+	writeJSONField(&buf, "msg", msg, false)
+	for _, f := range fields {
+		writeJSONField(&buf, f.Key, f.Value, false)
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+func writeJSONField(buf *bytes.Buffer, key string, value interface{}, first bool) {
+	if !first {
+		buf.WriteByte(',')
+	}
+	keyJSON, _ := json.Marshal(key)
+	buf.Write(keyJSON)
+	buf.WriteByte(':')
+	valJSON, err := json.Marshal(value)
+	if err != nil {
+		valJSON, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(valJSON)
+}
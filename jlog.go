@@ -33,13 +33,6 @@ import (
 	"path/filepath"
 )
 
-var (
-	dbg    *log.Logger
-	warn   *log.Logger
-	info   *log.Logger
-	errorl *log.Logger
-)
-
 const (
 	fileLog         = "jasmd.log"
 	clientsLogFile  = "jasmd_clients.log"
@@ -58,6 +51,46 @@ func CloseLog(f *os.File) {
 	f.Close()
 }
 
+// Options configures InitLogWithOptions. DefaultOptions matches the
+// historical behaviour of InitLog: no rotation, mirrored to stderr, debug
+// logging disabled. The zero value is the same except that it does not
+// mirror to stderr.
+type Options struct {
+	// MaxSizeMB, MaxBackups, MaxAgeDays and Compress configure
+	// RotatingWriter. Leaving all three size/backups/age fields zero
+	// disables rotation and falls back to a plain append-only file, same
+	// as before this option existed.
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
+
+	// MirrorStderr also writes log records to os.Stderr in addition to
+	// the log file.
+	MirrorStderr bool
+
+	// Debug enables DEBUG-level output; equivalent to setting the
+	// package-level Debug variable by hand.
+	Debug bool
+
+	// Sinks, if non-empty, replaces the built-in file(+stderr) sink
+	// entirely: every record is fanned out to each of them instead.
+	// MaxSizeMB/MaxBackups/MaxAgeDays/Compress/MirrorStderr are ignored
+	// in that case, since the caller owns the destinations. Use
+	// RegisterSink to add a sink alongside the default one instead of
+	// replacing it.
+	Sinks []Sink
+
+	// Format selects how the built-in file sink renders each record:
+	// FormatText (default) or FormatJSON. Ignored when Sinks is set.
+	Format Format
+}
+
+// DefaultOptions returns the Options used by InitLog.
+func DefaultOptions() Options {
+	return Options{MirrorStderr: true}
+}
+
 func setStateDir() (string, error) {
 	var (
 		stateDir string
@@ -79,38 +112,106 @@ func setStateDir() (string, error) {
 	return jasmStateDir, nil
 }
 
-//https://git.sr.ht/~rjarry/aerc/tree/master/item/log/logger.go
+// https://git.sr.ht/~rjarry/aerc/tree/master/item/log/logger.go
 // https://www.honeybadger.io/blog/golang-logging/
 // https://stackoverflow.com/questions/36719525/how-to-log-messages-to-the-console-and-a-file-both-in-golang
 func InitLog() (*os.File, error) {
-	stateDir, err := setStateDir()
+	closer, err := InitLogWithOptions(DefaultOptions())
 	if err != nil {
-		return nil, fmt.Errorf("%v", err)
+		return nil, err
 	}
-	f := filepath.Join(stateDir, fileLog)
+	// DefaultOptions never enables rotation, so InitLogWithOptions always
+	// hands back a plain *os.File here; CloseLog keeps working unchanged.
+	logFile, _ := closer.(*os.File)
+	return logFile, nil
+}
 
-	logFile, err := os.OpenFile(f, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
-	if err != nil {
-		return nil, fmt.Errorf("open logFile: %v", err)
+// InitLogWithOptions is the configurable counterpart to InitLog: it lets
+// callers turn on log rotation (see RotatingWriter), disable the stderr
+// mirror, replace the built-in file sink with their own Sinks, and toggle
+// debug output. The returned
+// io.Closer must be closed once the caller is done logging.
+func InitLogWithOptions(opts Options) (io.Closer, error) {
+	var sinks []Sink
+	var closer io.Closer
+
+	if len(opts.Sinks) > 0 {
+		sinks = opts.Sinks
+		closer = sinkCloser(opts.Sinks)
+	} else {
+		stateDir, err := setStateDir()
+		if err != nil {
+			return nil, fmt.Errorf("%v", err)
+		}
+		f := filepath.Join(stateDir, fileLog)
+
+		var w io.Writer
+		if opts.MaxSizeMB > 0 || opts.MaxBackups > 0 || opts.MaxAgeDays > 0 {
+			rw := &RotatingWriter{
+				Filename:   f,
+				MaxSizeMB:  opts.MaxSizeMB,
+				MaxBackups: opts.MaxBackups,
+				MaxAgeDays: opts.MaxAgeDays,
+				Compress:   opts.Compress,
+			}
+			closer, w = rw, rw
+		} else {
+			logFile, err := os.OpenFile(f, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+			if err != nil {
+				return nil, fmt.Errorf("open logFile: %v", err)
+			}
+			closer, w = logFile, logFile
+		}
+
+		if opts.MirrorStderr {
+			w = io.MultiWriter(os.Stderr, w)
+		}
+
+		sinks = []Sink{newFileSink(w, opts.Format)}
 	}
-	mw := io.MultiWriter(os.Stderr, logFile)
 
-	info = log.New(mw, "[jasmd] ", 0)
-	warn = log.New(mw, "WARNING: ", log.Ldate|log.Ltime|log.Lshortfile) // | bitwise OR
-	dbg = log.New(mw, "DEBUG: ", log.Ldate|log.Ltime|log.Lshortfile)    // | bitwise OR
-	//errorl = log.New(mw, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
-	errorl = log.New(mw, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
+	setSinks(sinks)
+	Debug = opts.Debug
 
-	return logFile, nil
+	return closer, nil
 }
 
+// InitClientLog opens jasmd_clients.log with the historical behaviour: no
+// rotation, plain append. Use InitClientLogWithOptions to turn on rotation.
 func InitClientLog() (*os.File, error) {
+	closer, err := InitClientLogWithOptions(Options{})
+	if err != nil {
+		return nil, err
+	}
+	// Options{} never enables rotation, so InitClientLogWithOptions always
+	// hands back a plain *os.File here; CloseLog keeps working unchanged.
+	logFile, _ := closer.(*os.File)
+	return logFile, nil
+}
+
+// InitClientLogWithOptions is the rotation-capable counterpart to
+// InitClientLog, mirroring InitLogWithOptions: setting MaxSizeMB,
+// MaxBackups or MaxAgeDays switches jasmd_clients.log to a RotatingWriter
+// instead of a plain append-only file. MirrorStderr/Sinks/Format/Debug are
+// ignored here, since jasmd_clients.log is a plain file, not a Sink.
+func InitClientLogWithOptions(opts Options) (io.Closer, error) {
 	stateDir, err := setStateDir()
 	if err != nil {
 		return nil, fmt.Errorf("%v", err)
 	}
 	f := filepath.Join(stateDir, clientsLogFile)
 
+	if opts.MaxSizeMB > 0 || opts.MaxBackups > 0 || opts.MaxAgeDays > 0 {
+		rw := &RotatingWriter{
+			Filename:   f,
+			MaxSizeMB:  opts.MaxSizeMB,
+			MaxBackups: opts.MaxBackups,
+			MaxAgeDays: opts.MaxAgeDays,
+			Compress:   opts.Compress,
+		}
+		return rw, nil
+	}
+
 	logFile, err := os.OpenFile(f, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
 	if err != nil {
 		return nil, fmt.Errorf("open nsmclients logFile: %v", err)
@@ -118,56 +219,49 @@ func InitClientLog() (*os.File, error) {
 	return logFile, nil
 }
 
+// ErrorLogger returns a *log.Logger that fans ERROR-level writes out to
+// every registered sink, for callers that only know the stdlib log.Logger
+// API. It never returns nil, even before InitLog runs.
 func ErrorLogger() *log.Logger {
-	if errorl == nil {
-		return log.New(io.Discard, "", log.LstdFlags)
-	}
-	return errorl
+	return log.New(severityWriter{SeverityError}, "", 0)
 }
 
 func Debugf(message string, args ...interface{}) {
-	if dbg == nil || !Debug { // NOTE edit
+	if !Debug || !hasSinks() { // NOTE edit
 		return
 	}
 	if len(args) > 0 {
 		message = fmt.Sprintf(message, args...)
 	}
-	dbg.Output(2, message) //nolint:errcheck // we can't do anything with what we log
+	emit(SeverityDebug, 2, message, nil)
 }
 
 func Infof(msg string, args ...interface{}) {
-	if info == nil { // NOTE always info
+	if !hasSinks() { // NOTE always info
 		return
 	}
 	if len(args) > 0 {
 		msg = fmt.Sprintf(msg, args...)
 	}
-	info.Output(1, msg) //nolint:errcheck // we can't do anything with what we log
+	emit(SeverityInfo, 2, msg, nil)
 }
 
-/*
-
-func Output(calldepth int, s string) error
-Calldepth is the count of the number of frames to skip when computing the file name and line number if Llongfile or Lshortfile is set; a value of 1 will print the details for the caller of Output.
-
-*/
-
 func Warnf(message string, args ...interface{}) {
-	if warn == nil { // NOTE always warn.
+	if !hasSinks() { // NOTE always warn.
 		return
 	}
 	if len(args) > 0 {
 		message = fmt.Sprintf(message, args...)
 	}
-	warn.Output(2, message) //nolint:errcheck // we can't do anything with what we log
+	emit(SeverityWarn, 2, message, nil)
 }
 
 func Errorf(message string, args ...interface{}) {
-	if errorl == nil { // || !Error {
+	if !hasSinks() { // || !Error {
 		return
 	}
 	if len(args) > 0 {
 		message = fmt.Sprintf(message, args...)
 	}
-	errorl.Output(2, message) //nolint:errcheck // we can't do anything with what we log
+	emit(SeverityError, 2, message, nil)
 }
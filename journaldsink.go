@@ -0,0 +1,136 @@
+/*
+Copyright (c) 2018-2019 Drew DeVault
+Copyright (c) 2021-2022 Robin Jarry
+
+The MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package jlog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// defaultJournaldSocket is where systemd-journald listens for the native
+// logging protocol; see systemd.journal-fields(7) and sd-journal's
+// sd_journal_sendv wire format.
+const defaultJournaldSocket = "/run/systemd/journal/socket"
+
+// journaldSink writes structured fields directly to the journald native
+// socket, bypassing syslog's line-oriented format.
+type journaldSink struct {
+	conn net.Conn
+}
+
+// NewJournaldSink connects to the local systemd-journald socket and returns
+// a Sink that writes PRIORITY=, CODE_FILE=, CODE_LINE= and MESSAGE= fields
+// for every record.
+func NewJournaldSink() (Sink, error) {
+	conn, err := net.Dial("unixgram", defaultJournaldSocket)
+	if err != nil {
+		return nil, fmt.Errorf("journald sink: %v", err)
+	}
+	return &journaldSink{conn: conn}, nil
+}
+
+func (s *journaldSink) Emit(severity Severity, file string, line int, msg string, fields []Field) {
+	var buf bytes.Buffer
+	writeJournaldField(&buf, "MESSAGE", msg)
+	writeJournaldField(&buf, "PRIORITY", strconv.Itoa(journaldPriority(severity)))
+	writeJournaldField(&buf, "CODE_FILE", file)
+	writeJournaldField(&buf, "CODE_LINE", strconv.Itoa(line))
+	for _, f := range fields {
+		writeJournaldField(&buf, journaldFieldName(f.Key), fmt.Sprint(f.Value))
+	}
+	s.conn.Write(buf.Bytes()) //nolint:errcheck // we can't do anything with what we log
+}
+
+func (s *journaldSink) Close() error {
+	return s.conn.Close()
+}
+
+// journaldPriority maps Severity onto the syslog(3) priority levels used by
+// the PRIORITY= field.
+func journaldPriority(severity Severity) int {
+	switch severity {
+	case SeverityDebug:
+		return 7 // LOG_DEBUG
+	case SeverityWarn:
+		return 4 // LOG_WARNING
+	case SeverityError:
+		return 3 // LOG_ERR
+	case SeverityFatal:
+		return 2 // LOG_CRIT
+	default:
+		return 6 // LOG_INFO
+	}
+}
+
+// journaldFieldName coerces key into a valid journald field name: uppercase
+// ASCII letters, digits and underscores only, not starting with a digit.
+func journaldFieldName(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z':
+			b.WriteRune(r - ('a' - 'A'))
+		case (r >= 'A' && r <= 'Z') || r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	name := b.String()
+	if name == "" {
+		return "FIELD"
+	}
+	return name
+}
+
+// writeJournaldField appends one field to buf using the native protocol:
+// "KEY=value\n" for single-line values, or "KEY\n<uint64 LE length><value>\n"
+// when value itself contains a newline.
+func writeJournaldField(buf *bytes.Buffer, key, value string) {
+	if !strings.ContainsRune(value, '\n') {
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteString(key)
+	buf.WriteByte('\n')
+	var length [8]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(value)))
+	buf.Write(length[:])
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
@@ -0,0 +1,223 @@
+/*
+Copyright (c) 2018-2019 Drew DeVault
+Copyright (c) 2021-2022 Robin Jarry
+
+The MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package jlog
+
+import "fmt"
+
+// Logger is a named logger carrying a fixed set of contextual fields,
+// returned by WithValues/WithName. It mirrors klog's InfoS/WithValues/
+// WithName surface on top of this package's existing severities and sinks.
+type Logger interface {
+	Infof(msg string, args ...interface{})
+	Warnf(msg string, args ...interface{})
+	Errorf(msg string, args ...interface{})
+	Debugf(msg string, args ...interface{})
+
+	InfoS(msg string, keysAndValues ...interface{})
+	WarnS(msg string, keysAndValues ...interface{})
+	ErrorS(msg string, keysAndValues ...interface{})
+	DebugS(msg string, keysAndValues ...interface{})
+
+	// WithValues returns a child Logger that prepends kv to every field
+	// list it logs, in addition to this Logger's own fields.
+	WithValues(kv ...interface{}) Logger
+	// WithName returns a child Logger whose name is joined to this
+	// Logger's name with "/".
+	WithName(name string) Logger
+}
+
+// logger is the concrete Logger implementation; the package-level
+// Infof/InfoS/WithValues/... functions all delegate to the unnamed root
+// logger.
+type logger struct {
+	name   string
+	fields []Field
+}
+
+var root = &logger{}
+
+// WithValues returns a Logger that prepends kv to every record it logs,
+// useful for per-client context such as jasmd_clients.log.
+func WithValues(kv ...interface{}) Logger {
+	return root.WithValues(kv...)
+}
+
+// WithName returns a Logger with a hierarchical name, joined with "/".
+func WithName(name string) Logger {
+	return root.WithName(name)
+}
+
+// The package-level *S functions below intentionally don't delegate to
+// root's methods: doing so would add a stack frame and throw off the
+// caller/line every Sink reports.
+
+func InfoS(msg string, keysAndValues ...interface{}) {
+	if !hasSinks() {
+		return
+	}
+	emit(SeverityInfo, 2, msg, root.allFields(keysAndValues))
+}
+
+func WarnS(msg string, keysAndValues ...interface{}) {
+	if !hasSinks() {
+		return
+	}
+	emit(SeverityWarn, 2, msg, root.allFields(keysAndValues))
+}
+
+func ErrorS(msg string, keysAndValues ...interface{}) {
+	if !hasSinks() {
+		return
+	}
+	emit(SeverityError, 2, msg, root.allFields(keysAndValues))
+}
+
+func DebugS(msg string, keysAndValues ...interface{}) {
+	if !Debug || !hasSinks() {
+		return
+	}
+	emit(SeverityDebug, 2, msg, root.allFields(keysAndValues))
+}
+
+func (l *logger) WithValues(kv ...interface{}) Logger {
+	extra := toFields(kv)
+	fields := make([]Field, 0, len(l.fields)+len(extra))
+	fields = append(fields, l.fields...)
+	fields = append(fields, extra...)
+	return &logger{name: l.name, fields: fields}
+}
+
+func (l *logger) WithName(name string) Logger {
+	joined := name
+	if l.name != "" {
+		joined = l.name + "/" + name
+	}
+	return &logger{name: joined, fields: l.fields}
+}
+
+func (l *logger) Infof(msg string, args ...interface{}) {
+	if !hasSinks() {
+		return
+	}
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	emit(SeverityInfo, 2, msg, l.allFields(nil))
+}
+
+func (l *logger) Warnf(msg string, args ...interface{}) {
+	if !hasSinks() {
+		return
+	}
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	emit(SeverityWarn, 2, msg, l.allFields(nil))
+}
+
+func (l *logger) Errorf(msg string, args ...interface{}) {
+	if !hasSinks() {
+		return
+	}
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	emit(SeverityError, 2, msg, l.allFields(nil))
+}
+
+func (l *logger) Debugf(msg string, args ...interface{}) {
+	if !Debug || !hasSinks() {
+		return
+	}
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	emit(SeverityDebug, 2, msg, l.allFields(nil))
+}
+
+func (l *logger) InfoS(msg string, keysAndValues ...interface{}) {
+	if !hasSinks() {
+		return
+	}
+	emit(SeverityInfo, 2, msg, l.allFields(keysAndValues))
+}
+
+func (l *logger) WarnS(msg string, keysAndValues ...interface{}) {
+	if !hasSinks() {
+		return
+	}
+	emit(SeverityWarn, 2, msg, l.allFields(keysAndValues))
+}
+
+func (l *logger) ErrorS(msg string, keysAndValues ...interface{}) {
+	if !hasSinks() {
+		return
+	}
+	emit(SeverityError, 2, msg, l.allFields(keysAndValues))
+}
+
+func (l *logger) DebugS(msg string, keysAndValues ...interface{}) {
+	if !Debug || !hasSinks() {
+		return
+	}
+	emit(SeverityDebug, 2, msg, l.allFields(keysAndValues))
+}
+
+// allFields merges this logger's own name/fields with keysAndValues from a
+// single call, in that order: name, WithValues fields, then call-site kv.
+func (l *logger) allFields(keysAndValues []interface{}) []Field {
+	extra := toFields(keysAndValues)
+	if l.name == "" && len(l.fields) == 0 && len(extra) == 0 {
+		return nil
+	}
+	fields := make([]Field, 0, 1+len(l.fields)+len(extra))
+	if l.name != "" {
+		fields = append(fields, Field{Key: "logger", Value: l.name})
+	}
+	fields = append(fields, l.fields...)
+	fields = append(fields, extra...)
+	return fields
+}
+
+// toFields pairs up keysAndValues into Fields. A trailing key with no value
+// gets "(MISSING)" rather than panicking, matching klog's InfoS behavior.
+func toFields(keysAndValues []interface{}) []Field {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+	if len(keysAndValues)%2 != 0 {
+		keysAndValues = append(keysAndValues, "(MISSING)")
+	}
+	fields := make([]Field, 0, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		fields = append(fields, Field{Key: key, Value: keysAndValues[i+1]})
+	}
+	return fields
+}
@@ -0,0 +1,61 @@
+/*
+Copyright (c) 2018-2019 Drew DeVault
+Copyright (c) 2021-2022 Robin Jarry
+
+The MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package jlog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToFieldsEven(t *testing.T) {
+	got := toFields([]interface{}{"a", 1, "b", "two"})
+	want := []Field{{Key: "a", Value: 1}, {Key: "b", Value: "two"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("toFields = %+v, want %+v", got, want)
+	}
+}
+
+func TestToFieldsOddAddsMissing(t *testing.T) {
+	got := toFields([]interface{}{"a", 1, "dangling"})
+	want := []Field{{Key: "a", Value: 1}, {Key: "dangling", Value: "(MISSING)"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("toFields = %+v, want %+v", got, want)
+	}
+}
+
+func TestToFieldsNonStringKey(t *testing.T) {
+	got := toFields([]interface{}{42, "value"})
+	want := []Field{{Key: "42", Value: "value"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("toFields = %+v, want %+v", got, want)
+	}
+}
+
+func TestToFieldsEmpty(t *testing.T) {
+	if got := toFields(nil); got != nil {
+		t.Errorf("toFields(nil) = %+v, want nil", got)
+	}
+}
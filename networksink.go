@@ -0,0 +1,60 @@
+/*
+Copyright (c) 2018-2019 Drew DeVault
+Copyright (c) 2021-2022 Robin Jarry
+
+The MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package jlog
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// networkSink ships records to a remote collector over TCP or UDP, one line
+// per record: "SEVERITY file:line: msg\n", with any fields appended to msg
+// as " key=value".
+type networkSink struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewNetworkSink dials address over network ("tcp" or "udp") and returns a
+// Sink that writes each record to it.
+func NewNetworkSink(network, address string) (Sink, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("network sink: %v", err)
+	}
+	return &networkSink{conn: conn}, nil
+}
+
+func (s *networkSink) Emit(severity Severity, file string, line int, msg string, fields []Field) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(s.conn, "%s %s:%d: %s%s\n", severity, file, line, msg, fieldsToText(fields)) //nolint:errcheck // we can't do anything with what we log
+}
+
+func (s *networkSink) Close() error {
+	return s.conn.Close()
+}
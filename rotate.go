@@ -0,0 +1,256 @@
+/*
+Copyright (c) 2018-2019 Drew DeVault
+Copyright (c) 2021-2022 Robin Jarry
+
+The MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package jlog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is embedded in rotated file names so MaxAgeDays pruning
+// can recover the rotation time without touching the filesystem mtime.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingWriter is an io.WriteCloser that writes to Filename, rotating it
+// once it grows past MaxSizeMB and pruning old backups by count and age.
+// It is modeled after gopkg.in/natefinch/lumberjack.v2.
+type RotatingWriter struct {
+	// Filename is the active log file. Rotated backups are written
+	// alongside it as "<name>-<timestamp><ext>" (optionally gzipped).
+	Filename string
+	// MaxSizeMB is the size, in megabytes, Filename may reach before
+	// it is rotated. Zero disables size-based rotation.
+	MaxSizeMB int
+	// MaxBackups is the number of old log files to retain. Zero keeps
+	// every backup.
+	MaxBackups int
+	// MaxAgeDays is the number of days to retain old log files, judged
+	// by the timestamp encoded in their name. Zero disables age-based
+	// pruning.
+	MaxAgeDays int
+	// Compress gzips rotated backups in the background. Write never
+	// blocks on compression or pruning.
+	Compress bool
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func (w *RotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err := w.openExisting(); err != nil {
+			return 0, err
+		}
+	}
+	if w.MaxSizeMB > 0 && w.size+int64(len(p)) > int64(w.MaxSizeMB)*1024*1024 {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *RotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func (w *RotatingWriter) openExisting() error {
+	info, err := os.Stat(w.Filename)
+	if os.IsNotExist(err) {
+		return w.openNew()
+	}
+	if err != nil {
+		return fmt.Errorf("stat %s: %v", w.Filename, err)
+	}
+	f, err := os.OpenFile(w.Filename, os.O_APPEND|os.O_WRONLY, 0666)
+	if err != nil {
+		return fmt.Errorf("open %s: %v", w.Filename, err)
+	}
+	w.file = f
+	w.size = info.Size()
+	return nil
+}
+
+func (w *RotatingWriter) openNew() error {
+	if err := os.MkdirAll(filepath.Dir(w.Filename), 0700); err != nil {
+		return fmt.Errorf("mkdir %s: %v", filepath.Dir(w.Filename), err)
+	}
+	f, err := os.OpenFile(w.Filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return fmt.Errorf("open %s: %v", w.Filename, err)
+	}
+	w.file = f
+	w.size = 0
+	return nil
+}
+
+func (w *RotatingWriter) rotate() error {
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+	backup := w.backupName()
+	if err := os.Rename(w.Filename, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rotate %s: %v", w.Filename, err)
+	}
+	// Compress (if enabled) and prune run in a single background goroutine,
+	// in that order: prune's listBackups walks the directory by name, and
+	// running it concurrently with compressBackup would see the rotated
+	// file mid-rename (visible under both its plain and .gz name, or gone
+	// out from under a still-open read) and mis-count backups against
+	// MaxBackups/MaxAgeDays.
+	go func() {
+		if w.Compress {
+			compressBackup(backup)
+		}
+		w.prune()
+	}()
+	return w.openNew()
+}
+
+func (w *RotatingWriter) backupName() string {
+	dir := filepath.Dir(w.Filename)
+	ext := filepath.Ext(w.Filename)
+	base := strings.TrimSuffix(filepath.Base(w.Filename), ext)
+	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", base, time.Now().Format(backupTimeFormat), ext))
+}
+
+// compressBackup gzips name in place, removing the uncompressed file once
+// the copy succeeds. Run in a goroutine so Write is never blocked on it.
+func compressBackup(name string) {
+	src, err := os.Open(name)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return
+	}
+	defer dst.Close()
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		os.Remove(name + ".gz")
+		return
+	}
+	if err := gz.Close(); err != nil {
+		os.Remove(name + ".gz")
+		return
+	}
+	os.Remove(name)
+}
+
+// backupFile is a rotated log file discovered on disk, with its rotation
+// time parsed back out of its name.
+type backupFile struct {
+	path string
+	ts   time.Time
+}
+
+// listBackups finds every rotated backup of Filename, newest first.
+func (w *RotatingWriter) listBackups() []backupFile {
+	dir := filepath.Dir(w.Filename)
+	ext := filepath.Ext(w.Filename)
+	base := strings.TrimSuffix(filepath.Base(w.Filename), ext)
+	prefix := base + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var backups []backupFile
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(name, prefix)
+		rest = strings.TrimSuffix(rest, ".gz")
+		rest = strings.TrimSuffix(rest, ext)
+		ts, err := time.ParseInLocation(backupTimeFormat, rest, time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), ts: ts})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].ts.After(backups[j].ts) })
+	return backups
+}
+
+// prune deletes backups beyond MaxBackups and older than MaxAgeDays.
+func (w *RotatingWriter) prune() {
+	backups := w.listBackups()
+	var toRemove []backupFile
+
+	if w.MaxBackups > 0 && len(backups) > w.MaxBackups {
+		toRemove = append(toRemove, backups[w.MaxBackups:]...)
+		backups = backups[:w.MaxBackups]
+	}
+	if w.MaxAgeDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -w.MaxAgeDays)
+		var kept []backupFile
+		for _, b := range backups {
+			if b.ts.Before(cutoff) {
+				toRemove = append(toRemove, b)
+			} else {
+				kept = append(kept, b)
+			}
+		}
+		backups = kept
+	}
+
+	for _, b := range toRemove {
+		os.Remove(b.path)
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright (c) 2018-2019 Drew DeVault
+Copyright (c) 2021-2022 Robin Jarry
+
+The MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package jlog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// makeBackup creates an empty rotated backup file named as rotate() would,
+// timestamped ts, so listBackups/prune can be exercised without going
+// through an actual rotation.
+func makeBackup(t *testing.T, dir, base, ext string, ts time.Time) string {
+	t.Helper()
+	name := filepath.Join(dir, base+"-"+ts.Format(backupTimeFormat)+ext)
+	if err := os.WriteFile(name, []byte("x"), 0666); err != nil {
+		t.Fatalf("WriteFile(%s): %v", name, err)
+	}
+	return name
+}
+
+func TestRotatingWriterPruneMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	w := &RotatingWriter{Filename: filepath.Join(dir, "app.log"), MaxBackups: 2}
+
+	now := time.Now()
+	oldest := makeBackup(t, dir, "app", ".log", now.Add(-3*time.Hour))
+	middle := makeBackup(t, dir, "app", ".log", now.Add(-2*time.Hour))
+	newest := makeBackup(t, dir, "app", ".log", now.Add(-1*time.Hour))
+
+	w.prune()
+
+	if _, err := os.Stat(oldest); !os.IsNotExist(err) {
+		t.Errorf("oldest backup %s should have been pruned, stat err = %v", oldest, err)
+	}
+	if _, err := os.Stat(middle); err != nil {
+		t.Errorf("middle backup %s should have survived: %v", middle, err)
+	}
+	if _, err := os.Stat(newest); err != nil {
+		t.Errorf("newest backup %s should have survived: %v", newest, err)
+	}
+}
+
+func TestRotatingWriterPruneMaxAgeDays(t *testing.T) {
+	dir := t.TempDir()
+	w := &RotatingWriter{Filename: filepath.Join(dir, "app.log"), MaxAgeDays: 1}
+
+	now := time.Now()
+	expired := makeBackup(t, dir, "app", ".log", now.AddDate(0, 0, -2))
+	fresh := makeBackup(t, dir, "app", ".log", now.Add(-1*time.Hour))
+
+	w.prune()
+
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Errorf("expired backup %s should have been pruned, stat err = %v", expired, err)
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Errorf("fresh backup %s should have survived: %v", fresh, err)
+	}
+}
+
+func TestRotatingWriterListBackupsNewestFirst(t *testing.T) {
+	dir := t.TempDir()
+	w := &RotatingWriter{Filename: filepath.Join(dir, "app.log")}
+
+	now := time.Now()
+	makeBackup(t, dir, "app", ".log", now.Add(-2*time.Hour))
+	makeBackup(t, dir, "app", ".log", now.Add(-1*time.Hour))
+
+	backups := w.listBackups()
+	if len(backups) != 2 {
+		t.Fatalf("got %d backups, want 2", len(backups))
+	}
+	if !backups[0].ts.After(backups[1].ts) {
+		t.Errorf("listBackups not newest-first: %+v", backups)
+	}
+}
+
+func TestRotatingWriterWriteRotates(t *testing.T) {
+	dir := t.TempDir()
+	w := &RotatingWriter{Filename: filepath.Join(dir, "app.log"), MaxSizeMB: 1}
+
+	small := make([]byte, 10)
+	if _, err := w.Write(small); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if w.size != int64(len(small)) {
+		t.Errorf("size = %d, want %d", w.size, len(small))
+	}
+
+	big := make([]byte, 2*1024*1024)
+	if _, err := w.Write(big); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if w.size != int64(len(big)) {
+		t.Errorf("size after rotation = %d, want %d", w.size, len(big))
+	}
+	if len(w.listBackups()) != 1 {
+		t.Errorf("got %d backups after rotation, want 1", len(w.listBackups()))
+	}
+	w.Close()
+}
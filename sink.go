@@ -0,0 +1,256 @@
+/*
+Copyright (c) 2018-2019 Drew DeVault
+Copyright (c) 2021-2022 Robin Jarry
+
+The MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package jlog
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Severity is the level of a single log record: Debug, Info, Warn, Error or
+// Fatal.
+// It is distinct from Level, which is the -v/-vmodule verbosity a call is
+// gated behind; Severity is what mature Go loggers like glog and klog call
+// out as the record, kept separate from where it ends up.
+type Severity int
+
+const (
+	SeverityDebug Severity = iota
+	SeverityInfo
+	SeverityWarn
+	SeverityError
+	SeverityFatal
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityDebug:
+		return "DEBUG"
+	case SeverityInfo:
+		return "INFO"
+	case SeverityWarn:
+		return "WARNING"
+	case SeverityError:
+		return "ERROR"
+	case SeverityFatal:
+		return "FATAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Field is one key/value pair attached to a log record by InfoS/WarnS/
+// ErrorS/DebugS or by a Logger built with WithValues.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Format selects how a Sink renders a record. It currently only affects
+// fileSink; sinks with their own wire format (syslog, journald, network)
+// choose their own representation regardless.
+type Format int
+
+const (
+	FormatText Format = iota
+	FormatJSON
+)
+
+// Sink is a log destination. Implementations must be safe for concurrent
+// use, since every log call fans out to every registered sink. Sinks that
+// hold an open resource (a file, a syslog or network connection) should
+// also implement io.Closer; closing the io.Closer returned by
+// InitLogWithOptions closes those among Options.Sinks. Sinks added with
+// RegisterSink are not closed by this package.
+type Sink interface {
+	Emit(severity Severity, file string, line int, msg string, fields []Field)
+}
+
+var (
+	sinksMu     sync.RWMutex
+	activeSinks []Sink
+)
+
+// setSinks replaces the full set of active sinks, as used by
+// InitLogWithOptions.
+func setSinks(sinks []Sink) {
+	sinksMu.Lock()
+	activeSinks = sinks
+	sinksMu.Unlock()
+}
+
+// RegisterSink adds sink alongside whatever InitLog/InitLogWithOptions
+// already configured, so callers can plug in e.g. a syslog or network sink
+// without giving up the local file mirror.
+func RegisterSink(sink Sink) {
+	sinksMu.Lock()
+	activeSinks = append(activeSinks, sink)
+	sinksMu.Unlock()
+}
+
+func hasSinks() bool {
+	sinksMu.RLock()
+	defer sinksMu.RUnlock()
+	return len(activeSinks) > 0
+}
+
+// queueDepth bounds how many records emit() can buffer before it falls back
+// to dispatching synchronously, so a burst of logging cannot grow memory
+// without limit.
+const queueDepth = 1024
+
+type logItem struct {
+	severity Severity
+	file     string
+	line     int
+	msg      string
+	fields   []Field
+	flushed  chan struct{} // non-nil: this item is a Flush() marker, not a record
+}
+
+var (
+	logQueue  = make(chan logItem, queueDepth)
+	workerRun sync.Once
+)
+
+func startWorker() {
+	workerRun.Do(func() {
+		go func() {
+			for item := range logQueue {
+				if item.flushed != nil {
+					close(item.flushed)
+					continue
+				}
+				dispatch(item.severity, item.file, item.line, item.msg, item.fields)
+			}
+		}()
+	})
+}
+
+// callerLocation resolves the file and line calldepth frames up the stack
+// from callerLocation itself, as runtime.Caller counts them: calldepth 1 is
+// callerLocation's own caller.
+func callerLocation(calldepth int) (string, int) {
+	if _, f, l, ok := runtime.Caller(calldepth); ok {
+		return filepath.Base(f), l
+	}
+	return "???", 0
+}
+
+// emit resolves the caller's file and line calldepth frames up the stack
+// and queues the record for the background worker to fan out to every
+// active sink, so a normal log call never blocks on a slow sink. If the
+// queue is full, it falls back to dispatching inline rather than dropping
+// the record.
+func emit(severity Severity, calldepth int, msg string, fields []Field) {
+	file, line := callerLocation(calldepth + 1)
+	if logBacktraceAt.matches(file, line) {
+		fields = append(append([]Field{}, fields...), Field{Key: "stacktrace", Value: string(stackTrace(false))})
+	}
+
+	startWorker()
+	select {
+	case logQueue <- logItem{severity: severity, file: file, line: line, msg: msg, fields: fields}:
+	default:
+		dispatch(severity, file, line, msg, fields)
+	}
+}
+
+// dispatch fans a record out to every active sink. A sink that panics does
+// not prevent the others from receiving the record.
+func dispatch(severity Severity, file string, line int, msg string, fields []Field) {
+	sinksMu.RLock()
+	sinks := activeSinks
+	sinksMu.RUnlock()
+
+	for _, s := range sinks {
+		emitOne(s, severity, file, line, msg, fields)
+	}
+}
+
+func emitOne(s Sink, severity Severity, file string, line int, msg string, fields []Field) {
+	defer func() { recover() }() //nolint:errcheck // one bad sink must not block the others
+	s.Emit(severity, file, line, msg, fields)
+}
+
+// Flush blocks until every record queued before this call has been
+// dispatched to all active sinks.
+func Flush() {
+	startWorker()
+	done := make(chan struct{})
+	logQueue <- logItem{flushed: done}
+	<-done
+}
+
+// sinkCloser closes every sink in the slice that implements io.Closer.
+type sinkCloser []Sink
+
+func (c sinkCloser) Close() error {
+	var firstErr error
+	for _, s := range c {
+		if closer, ok := s.(io.Closer); ok {
+			if err := closer.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// severityWriter adapts a fixed Severity to io.Writer so the stdlib
+// log.Logger returned by ErrorLogger can write into the sink registry.
+type severityWriter struct {
+	severity Severity
+}
+
+func (w severityWriter) Write(p []byte) (int, error) {
+	msg := string(p)
+	for len(msg) > 0 && (msg[len(msg)-1] == '\n' || msg[len(msg)-1] == '\r') {
+		msg = msg[:len(msg)-1]
+	}
+	emit(w.severity, 4, msg, nil)
+	return len(p), nil
+}
+
+// fieldsToText renders fields as " key=value key2=value2", for sinks whose
+// wire format is plain text.
+func fieldsToText(fields []Field) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		fmt.Fprintf(&b, "%v", f.Value)
+	}
+	return b.String()
+}
@@ -0,0 +1,69 @@
+/*
+Copyright (c) 2018-2019 Drew DeVault
+Copyright (c) 2021-2022 Robin Jarry
+
+The MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package jlog
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// syslogSink forwards records to the local syslog daemon, mapping Severity
+// onto the matching syslog priority (Debug->LOG_DEBUG, Warn->LOG_WARNING,
+// Error->LOG_ERR).
+type syslogSink struct {
+	w *syslog.Writer
+}
+
+// NewSyslogSink dials the local syslog daemon and returns a Sink that
+// forwards every record to it under tag. Callers typically pass the result
+// to RegisterSink or Options.Sinks.
+func NewSyslogSink(tag string) (Sink, error) {
+	w, err := syslog.New(syslog.LOG_INFO, tag)
+	if err != nil {
+		return nil, fmt.Errorf("syslog sink: %v", err)
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) Emit(severity Severity, file string, line int, msg string, fields []Field) {
+	text := fmt.Sprintf("%s:%d: %s%s", file, line, msg, fieldsToText(fields))
+	switch severity {
+	case SeverityDebug:
+		s.w.Debug(text) //nolint:errcheck // we can't do anything with what we log
+	case SeverityInfo:
+		s.w.Info(text) //nolint:errcheck // we can't do anything with what we log
+	case SeverityWarn:
+		s.w.Warning(text) //nolint:errcheck // we can't do anything with what we log
+	case SeverityError:
+		s.w.Err(text) //nolint:errcheck // we can't do anything with what we log
+	case SeverityFatal:
+		s.w.Crit(text) //nolint:errcheck // we can't do anything with what we log
+	}
+}
+
+func (s *syslogSink) Close() error {
+	return s.w.Close()
+}
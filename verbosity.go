@@ -0,0 +1,241 @@
+/*
+Copyright (c) 2018-2019 Drew DeVault
+Copyright (c) 2021-2022 Robin Jarry
+
+The MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package jlog
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Level is a verbosity level, as set by the -v and -vmodule flags.
+//
+// https://github.com/kubernetes/klog and glog use the same idea: callers
+// guard expensive log statements behind V(n) so that the arguments are only
+// evaluated when logging at that level is actually enabled.
+type Level int32
+
+// Verbose is returned by V and is a bool-like type: it evaluates to true
+// when the verbosity check passed, so callers can write
+//
+//	if v := V(2); v {
+//	        v.Infof("expensive %v", compute())
+//	}
+//
+// and skip the `compute()` call entirely when -v is below 2.
+type Verbose bool
+
+var verbosity Level // set by the -v flag
+
+// site caches the effective verbosity threshold for a callsite, keyed by the
+// PC of the V() call. It is swapped for a fresh, empty map whenever vmodule
+// is reparsed so that stale entries cannot linger.
+var site atomic.Value // holds *sync.Map
+
+func init() {
+	site.Store(&sync.Map{})
+	flag.Var((*levelFlag)(&verbosity), "v", "log level for V logs")
+	flag.Var(&vmodule, "vmodule", "comma-separated list of pattern=N settings for file-filtered logging")
+}
+
+// levelFlag implements flag.Value so -v can be parsed directly into a Level.
+type levelFlag Level
+
+func (l *levelFlag) String() string { return strconv.Itoa(int(*l)) }
+
+func (l *levelFlag) Set(s string) error {
+	v, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt32((*int32)(l), int32(v))
+	resetSiteCache()
+	return nil
+}
+
+// modulePat is one `pattern=level` entry from -vmodule.
+type modulePat struct {
+	pattern string
+	literal bool // pattern has no wildcard and no path separator
+	level   Level
+}
+
+func (m *modulePat) match(file string) bool {
+	if m.literal {
+		return m.pattern == file
+	}
+	ok, _ := filepath.Match(m.pattern, file)
+	return ok
+}
+
+// moduleSpec holds the parsed -vmodule filters and implements flag.Value.
+type moduleSpec struct {
+	mu      sync.Mutex
+	filters []modulePat
+}
+
+var vmodule moduleSpec
+
+func (m *moduleSpec) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	parts := make([]string, 0, len(m.filters))
+	for _, f := range m.filters {
+		parts = append(parts, fmt.Sprintf("%s=%d", f.pattern, f.level))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (m *moduleSpec) Set(value string) error {
+	var filters []modulePat
+	for _, entry := range strings.Split(value, ",") {
+		if entry == "" {
+			continue
+		}
+		eq := strings.LastIndex(entry, "=")
+		if eq < 0 {
+			return fmt.Errorf("vmodule: invalid entry %q, want pattern=N", entry)
+		}
+		pattern, levelStr := entry[:eq], entry[eq+1:]
+		lvl, err := strconv.ParseInt(levelStr, 10, 32)
+		if err != nil {
+			return fmt.Errorf("vmodule: invalid level in %q: %v", entry, err)
+		}
+		filters = append(filters, modulePat{
+			pattern: pattern,
+			literal: !strings.ContainsAny(pattern, `*?[/`),
+			level:   Level(lvl),
+		})
+	}
+	m.mu.Lock()
+	m.filters = filters
+	m.mu.Unlock()
+	resetSiteCache()
+	return nil
+}
+
+// resetSiteCache drops every cached per-callsite verbosity so that the next
+// V() call at each site is resolved against the new flags.
+func resetSiteCache() {
+	site.Store(&sync.Map{})
+}
+
+// V reports whether verbosity at the caller's callsite is at least level.
+// The result is cached per-PC in a sync.Map so that repeated calls from the
+// same callsite only pay for a runtime.Caller lookup, an atomic load and a
+// map lookup.
+func V(level Level) Verbose {
+	pc, _, _, ok := runtime.Caller(1)
+	if !ok {
+		return Verbose(Level(atomic.LoadInt32((*int32)(&verbosity))) >= level)
+	}
+
+	cache := site.Load().(*sync.Map)
+	if threshold, ok := cache.Load(pc); ok {
+		return Verbose(threshold.(Level) >= level)
+	}
+
+	threshold := callsiteLevel(pc)
+	cache.Store(pc, threshold)
+	return Verbose(threshold >= level)
+}
+
+// callsiteLevel resolves the effective verbosity threshold for pc by
+// matching its source file against the -vmodule filters, falling back to
+// the global -v level when nothing matches.
+func callsiteLevel(pc uintptr) Level {
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return Level(atomic.LoadInt32((*int32)(&verbosity)))
+	}
+	file, _ := fn.FileLine(pc)
+	base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+	vmodule.mu.Lock()
+	defer vmodule.mu.Unlock()
+	for _, f := range vmodule.filters {
+		if f.literal {
+			if f.match(base) {
+				return f.level
+			}
+			continue
+		}
+		if f.match(file) {
+			return f.level
+		}
+	}
+	return Level(atomic.LoadInt32((*int32)(&verbosity)))
+}
+
+// The methods below intentionally don't forward to the package-level
+// Infof/Warnf/Errorf/Debugf: those hard-code a calldepth that assumes a
+// direct call from user code, and forwarding through them would report
+// every V(n).Xxxf call site as verbosity.go instead of the real caller.
+
+func (v Verbose) Infof(msg string, args ...interface{}) {
+	if !bool(v) || !hasSinks() {
+		return
+	}
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	emit(SeverityInfo, 2, msg, nil)
+}
+
+func (v Verbose) Warnf(msg string, args ...interface{}) {
+	if !bool(v) || !hasSinks() {
+		return
+	}
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	emit(SeverityWarn, 2, msg, nil)
+}
+
+func (v Verbose) Errorf(msg string, args ...interface{}) {
+	if !bool(v) || !hasSinks() {
+		return
+	}
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	emit(SeverityError, 2, msg, nil)
+}
+
+func (v Verbose) Debugf(msg string, args ...interface{}) {
+	if !bool(v) || !Debug || !hasSinks() {
+		return
+	}
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	emit(SeverityDebug, 2, msg, nil)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright (c) 2018-2019 Drew DeVault
+Copyright (c) 2021-2022 Robin Jarry
+
+The MIT License
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of
+this software and associated documentation files (the "Software"), to deal in
+the Software without restriction, including without limitation the rights to
+use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies
+of the Software, and to permit persons to whom the Software is furnished to do
+so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package jlog
+
+import "testing"
+
+func TestModulePatMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		literal bool
+		file    string
+		want    bool
+	}{
+		{"verbosity", true, "verbosity", true},
+		{"verbosity", true, "verbosity.go", false},
+		{"*.go", false, "verbosity.go", true},
+		{"*.go", false, "path/verbosity.go", false},
+		{"path/*.go", false, "path/verbosity.go", true},
+		{"path/*.go", false, "other/verbosity.go", false},
+	}
+	for _, c := range cases {
+		m := modulePat{pattern: c.pattern, literal: c.literal}
+		if got := m.match(c.file); got != c.want {
+			t.Errorf("modulePat{%q, literal=%v}.match(%q) = %v, want %v",
+				c.pattern, c.literal, c.file, got, c.want)
+		}
+	}
+}
+
+func TestModuleSpecSet(t *testing.T) {
+	var m moduleSpec
+	if err := m.Set("foo=2,bar/*=3"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if len(m.filters) != 2 {
+		t.Fatalf("got %d filters, want 2", len(m.filters))
+	}
+	if !m.filters[0].literal || m.filters[0].level != 2 {
+		t.Errorf("filters[0] = %+v, want literal level=2", m.filters[0])
+	}
+	if m.filters[1].literal || m.filters[1].level != 3 {
+		t.Errorf("filters[1] = %+v, want non-literal level=3", m.filters[1])
+	}
+
+	if err := m.Set("bad-entry"); err == nil {
+		t.Error("Set(\"bad-entry\") returned nil error, want an error")
+	}
+}
+
+func TestModuleSpecString(t *testing.T) {
+	var m moduleSpec
+	if err := m.Set("foo=2"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got, want := m.String(), "foo=2"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}